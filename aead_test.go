@@ -0,0 +1,70 @@
+package symcrypto
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"testing"
+)
+
+func TestCrypterImplementsAEAD(t *testing.T) {
+	var _ cipher.AEAD = defCrypto(t).(*crypter)
+}
+
+func TestEncryptWithContext(t *testing.T) {
+	crypto := defCrypto(t)
+
+	msg := "hello world"
+	encrypted, err := crypto.(ContextCrypter).EncryptWithContext(msg, "user:42")
+	if err != nil {
+		t.Fatalf("unexpected err from encrypt string %q: %+v\n", msg, err)
+	}
+
+	decrypted, err := crypto.(ContextCrypter).DecryptWithContext(encrypted, "user:42")
+	if err != nil {
+		t.Fatalf("unexpected err from decrypt string %q: %+v\n", encrypted, err)
+	}
+	if decrypted != msg {
+		t.Errorf("expected decrypted string to be %q, got %q\n", msg, decrypted)
+	}
+
+	t.Run("wrong context is rejected", func(t *testing.T) {
+		wantErr := fmt.Sprintf("failed to decrypt %q", encrypted)
+		_, err := crypto.(ContextCrypter).DecryptWithContext(encrypted, "user:43")
+		if err == nil || err.Error() != wantErr {
+			t.Errorf("expected error %q, got %q", wantErr, err)
+		}
+	})
+
+	t.Run("plain Decrypt cannot open a context-bound token", func(t *testing.T) {
+		wantErr := fmt.Sprintf("failed to decrypt %q", encrypted)
+		_, err := crypto.Decrypt(encrypted)
+		if err == nil || err.Error() != wantErr {
+			t.Errorf("expected error %q, got %q", wantErr, err)
+		}
+	})
+}
+
+func TestAEADSealOpen(t *testing.T) {
+	aead := defCrypto(t).(*crypter)
+
+	nonce := make([]byte, aead.NonceSize())
+	plaintext := []byte("hello world")
+	aad := []byte("purpose:test")
+
+	sealed := aead.Seal(nil, nonce, plaintext, aad)
+
+	opened, err := aead.Open(nil, nonce, sealed, aad)
+	if err != nil {
+		t.Fatalf("unexpected error opening sealed message: %+v", err)
+	}
+	if string(opened) != string(plaintext) {
+		t.Errorf("expected opened plaintext to be %q, got %q", plaintext, opened)
+	}
+
+	t.Run("wrong additional data fails to open", func(t *testing.T) {
+		_, err := aead.Open(nil, nonce, sealed, []byte("purpose:other"))
+		if err == nil {
+			t.Error("expected an error opening with mismatched additional data")
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package symcrypto
+
+import (
+	"crypto/rand"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// AsymKeyLen is the length in bytes of a nacl/box public or private key.
+const AsymKeyLen = 32
+
+// AsymCrypter does asymmetric encryption/decryption using golang.org/x/crypto/nacl/box.
+// Unlike Crypter, it lets a message be encrypted for a specific recipient without the
+// sender and the recipient having to pre-share a symmetric secret. The encrypted string
+// is URL safe via base64 encoding.
+type AsymCrypter interface {
+	// Encrypt encrypts the given message for the configured peer and returns the
+	// encrypted string. The encrypted string is encoded using base64 so that it can be
+	// used in the URL.
+	Encrypt(string) (string, error)
+
+	// Decrypt decrypts and returns the given token. The encrypted string is expected to
+	// be base64 encoded.
+	Decrypt(string) (string, error)
+}
+
+// asymCrypter is a private struct which implements AsymCrypter. It can only be
+// instantiated via NewAsym or NewAnonymous.
+type asymCrypter struct {
+	priv, pub, peerPub *[AsymKeyLen]byte
+	anonymous          bool
+}
+
+// GenerateKeyPair generates a new public/private key pair suitable for use with NewAsym
+// and NewAnonymous.
+func GenerateKeyPair() (pub, priv [AsymKeyLen]byte, err error) {
+	pubPtr, privPtr, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return pub, priv, errors.Wrap(err, "failed to generate key pair")
+	}
+
+	return *pubPtr, *privPtr, nil
+}
+
+// NewAsym creates an instance of AsymCrypter which authenticates and encrypts messages
+// for the peer identified by peerPub, using priv to authenticate them. The same instance can
+// decrypt messages sent back by that peer, as well as anonymous messages - see
+// NewAnonymous - addressed to priv's corresponding public key.
+func NewAsym(priv, peerPub [AsymKeyLen]byte) (AsymCrypter, error) {
+	var pub [AsymKeyLen]byte
+	curve25519.ScalarBaseMult(&pub, &priv)
+
+	return &asymCrypter{priv: &priv, pub: &pub, peerPub: &peerPub}, nil
+}
+
+// NewAnonymous creates an instance of AsymCrypter which can encrypt messages for the
+// peer identified by peerPub without the sender needing a key pair of their own. The
+// returned instance cannot decrypt - only the holder of peerPub's corresponding private
+// key, via NewAsym, can do that.
+func NewAnonymous(peerPub [AsymKeyLen]byte) (AsymCrypter, error) {
+	return &asymCrypter{peerPub: &peerPub, anonymous: true}, nil
+}
+
+func (c *asymCrypter) Encrypt(msg string) (string, error) {
+	if c.anonymous {
+		sealed, err := box.SealAnonymous(nil, []byte(msg), c.peerPub, rand.Reader)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to seal message anonymously")
+		}
+
+		return encoding.EncodeToString(sealed), nil
+	}
+
+	// Use a different nonce for each message encrypted with the same key pair. Since the
+	// nonce here is 192 bits long, a random value provides a sufficiently small
+	// probability of repeats.
+	var nonce [nonceLen]byte
+	if _, err := rand.Reader.Read(nonce[:]); err != nil {
+		return "", errors.Wrap(err, "failed to generate nonce")
+	}
+
+	// This encrypts the message and appends the result to the nonce.
+	sealed := box.Seal(nonce[:], []byte(msg), &nonce, c.peerPub, c.priv)
+	return encoding.EncodeToString(sealed), nil
+}
+
+func (c *asymCrypter) Decrypt(msg string) (string, error) {
+	crypticBytes, err := encoding.DecodeString(msg)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decode %q using base64", msg)
+	}
+
+	// An anonymously sealed box carries the sender's ephemeral public key inside itself,
+	// so it can be opened with just our own key pair, regardless of who the configured
+	// peer is. Try this first so that an AsymCrypter created via NewAsym can also receive
+	// anonymous messages addressed to it.
+	if c.pub != nil && c.priv != nil {
+		if decrypted, ok := box.OpenAnonymous(nil, crypticBytes, c.pub, c.priv); ok {
+			return string(decrypted), nil
+		}
+	}
+
+	if c.peerPub == nil || c.priv == nil {
+		return "", errors.Errorf("failed to decrypt %q", msg)
+	}
+	if len(crypticBytes) <= nonceLen {
+		// The encrypted message can't possibly be shorter than 24 chars which is
+		// supposed to be just the nonce! By doing this check, we also avoid a panic:
+		// panic: runtime error: slice bounds out of range
+		return "", errors.Errorf("invalid encrypted message, %q is too short", msg)
+	}
+
+	var nonce [nonceLen]byte
+	copy(nonce[:], crypticBytes[:nonceLen])
+
+	decrypted, ok := box.Open(nil, crypticBytes[nonceLen:], &nonce, c.peerPub, c.priv)
+	if !ok {
+		return "", errors.Errorf("failed to decrypt %q", msg)
+	}
+
+	return string(decrypted), nil
+}
+
+// KeyToBytes returns the raw bytes backing a nacl/box key, suitable for storing
+// alongside other binary secrets.
+func KeyToBytes(key [AsymKeyLen]byte) []byte {
+	return key[:]
+}
+
+// KeyFromBytes converts raw bytes, as returned by KeyToBytes, back into a nacl/box key.
+// b must be exactly AsymKeyLen bytes long.
+func KeyFromBytes(b []byte) ([AsymKeyLen]byte, error) {
+	var key [AsymKeyLen]byte
+	if len(b) != AsymKeyLen {
+		return key, errors.Errorf("expected key bytes length to be %d, got %d", AsymKeyLen, len(b))
+	}
+
+	copy(key[:], b)
+	return key, nil
+}
+
+// EncodeKey base64 encodes a nacl/box key so that it can be persisted or transmitted as
+// text.
+func EncodeKey(key [AsymKeyLen]byte) string {
+	return encoding.EncodeToString(key[:])
+}
+
+// DecodeKey decodes a base64 encoded key produced by EncodeKey.
+func DecodeKey(s string) ([AsymKeyLen]byte, error) {
+	b, err := encoding.DecodeString(s)
+	if err != nil {
+		return [AsymKeyLen]byte{}, errors.Wrapf(err, "failed to decode %q using base64", s)
+	}
+
+	return KeyFromBytes(b)
+}
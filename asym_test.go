@@ -0,0 +1,146 @@
+package symcrypto
+
+import (
+	"fmt"
+	"testing"
+)
+
+func mustKeyPair(t *testing.T) (pub, priv [AsymKeyLen]byte) {
+	pub, priv, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("could not generate key pair: %+v\n", err)
+	}
+
+	return pub, priv
+}
+
+func TestKeySerialization(t *testing.T) {
+	_, key := mustKeyPair(t)
+
+	t.Run("raw bytes round trip", func(t *testing.T) {
+		got, err := KeyFromBytes(KeyToBytes(key))
+		if err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		if got != key {
+			t.Error("expected key to survive a raw bytes round trip unchanged")
+		}
+	})
+
+	t.Run("base64 round trip", func(t *testing.T) {
+		got, err := DecodeKey(EncodeKey(key))
+		if err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		if got != key {
+			t.Error("expected key to survive a base64 round trip unchanged")
+		}
+	})
+
+	t.Run("wrong length", func(t *testing.T) {
+		wantErr := fmt.Sprintf("expected key bytes length to be %d, got %d", AsymKeyLen, 4)
+		_, err := KeyFromBytes([]byte{1, 2, 3, 4})
+		if err == nil || err.Error() != wantErr {
+			t.Errorf("expected error %q, got %q", wantErr, err)
+		}
+	})
+}
+
+func TestAsymE2E(t *testing.T) {
+	alicePub, alicePriv := mustKeyPair(t)
+	bobPub, bobPriv := mustKeyPair(t)
+
+	alice, err := NewAsym(alicePriv, bobPub)
+	if err != nil {
+		t.Fatalf("could not create an instance of AsymCrypter: %+v\n", err)
+	}
+	bob, err := NewAsym(bobPriv, alicePub)
+	if err != nil {
+		t.Fatalf("could not create an instance of AsymCrypter: %+v\n", err)
+	}
+
+	msgs := []string{"", "hello world", "⮕😃⬅", "123456"}
+
+	for _, msg := range msgs {
+		t.Run(fmt.Sprintf("encrypt %q", msg), func(t *testing.T) {
+			encrypted, err := alice.Encrypt(msg)
+			if err != nil {
+				t.Fatalf("unexpected err from encrypt string %q: %+v\n", msg, err)
+			}
+
+			decrypted, err := bob.Decrypt(encrypted)
+			if err != nil {
+				t.Fatalf("unexpected err from decrypt string %q: %+v\n", encrypted, err)
+			}
+			if decrypted != msg {
+				t.Errorf("expected decrypted string to be %q, got %q\n", msg, decrypted)
+			}
+		})
+	}
+}
+
+func TestAnonymousE2E(t *testing.T) {
+	bobPub, bobPriv := mustKeyPair(t)
+
+	sender, err := NewAnonymous(bobPub)
+	if err != nil {
+		t.Fatalf("could not create an instance of AsymCrypter: %+v\n", err)
+	}
+	bob, err := NewAsym(bobPriv, bobPub)
+	if err != nil {
+		t.Fatalf("could not create an instance of AsymCrypter: %+v\n", err)
+	}
+
+	msg := "hello from a stranger"
+	encrypted, err := sender.Encrypt(msg)
+	if err != nil {
+		t.Fatalf("unexpected err from encrypt string %q: %+v\n", msg, err)
+	}
+
+	decrypted, err := bob.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("unexpected err from decrypt string %q: %+v\n", encrypted, err)
+	}
+	if decrypted != msg {
+		t.Errorf("expected decrypted string to be %q, got %q\n", msg, decrypted)
+	}
+
+	t.Run("anonymous crypter cannot decrypt", func(t *testing.T) {
+		wantErr := fmt.Sprintf("failed to decrypt %q", encrypted)
+		_, err := sender.Decrypt(encrypted)
+		if err == nil || err.Error() != wantErr {
+			t.Errorf("expected error %q, got %q", wantErr, err)
+		}
+	})
+}
+
+func TestAsymDecrypt(t *testing.T) {
+	_, priv := mustKeyPair(t)
+	peerPub, _ := mustKeyPair(t)
+
+	crypto, err := NewAsym(priv, peerPub)
+	if err != nil {
+		t.Fatalf("could not create an instance of AsymCrypter: %+v\n", err)
+	}
+
+	errCases := []struct {
+		name, msg, wantErr string
+	}{
+		{"illegal base64", "/", `failed to decode "/" using base64: illegal base64 data at input byte 0`},
+		{"invalid(empty) encrypted msg", "", `invalid encrypted message, "" is too short`},
+		{
+			"arbitrary string",
+			"some_string_which_was_not_encrypted_using_symcrypto",
+			`failed to decrypt "some_string_which_was_not_encrypted_using_symcrypto"`,
+		},
+	}
+
+	for _, c := range errCases {
+		t.Run("err/"+c.name, func(t *testing.T) {
+			_, err := crypto.Decrypt(c.msg)
+			if err == nil || err.Error() != c.wantErr {
+				t.Errorf("expected error %q, got %q", c.wantErr, err)
+			}
+		})
+	}
+}
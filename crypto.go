@@ -126,9 +126,12 @@ func getMidBytes(bytes []byte, size int) ([]byte, error) {
 // the same secret key.
 //
 // It is recommedded to load your secret key from a safe place and use it for
-// instantiating a Crypter instance. If you want to convert a passphrase to a key, use a
-// suitable package like bcrypt or scrypt. The secret key must be at least 32 chars long.
-// If the length exceeds 32 chars, the mid 32 chars will be used as the secret key.
+// instantiating a Crypter instance. The secret key must be at least 32 chars long. If
+// the length exceeds 32 chars, the mid 32 chars will be used as the secret key.
+//
+// Deprecated: taking the mid 32 chars of an arbitrary string is not a substitute for a
+// proper key derivation function. If you want to convert a passphrase to a key, use
+// NewFromPassphrase instead.
 func New(secret string) (Crypter, error) {
 	// See https://godoc.org/golang.org/x/crypto/nacl/secretbox
 	// Take the middle 32 bytes from given secret key.
@@ -0,0 +1,98 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/sudo-suhas/symcrypto"
+)
+
+func mustKEK(t *testing.T) symcrypto.Crypter {
+	kek, err := symcrypto.New("kek_secret_key_with_string_len_32")
+	if err != nil {
+		t.Fatalf("could not create KEK crypter: %+v\n", err)
+	}
+	return kek
+}
+
+func TestEncrypterE2E(t *testing.T) {
+	ctx := context.Background()
+	provider := NewStaticKeyProvider(mustKEK(t), "kek-1")
+	enc := New(provider)
+
+	msg := "hello world"
+	token, err := enc.Encrypt(ctx, msg)
+	if err != nil {
+		t.Fatalf("unexpected err from encrypt string %q: %+v\n", msg, err)
+	}
+
+	decrypted, err := enc.Decrypt(ctx, token)
+	if err != nil {
+		t.Fatalf("unexpected err from decrypt token %q: %+v\n", token, err)
+	}
+	if decrypted != msg {
+		t.Errorf("expected decrypted string to be %q, got %q\n", msg, decrypted)
+	}
+}
+
+func TestEncrypterUsesFreshDataKeyPerMessage(t *testing.T) {
+	ctx := context.Background()
+	provider := NewStaticKeyProvider(mustKEK(t), "kek-1")
+	enc := New(provider)
+
+	msg := "hello world"
+	token1, err := enc.Encrypt(ctx, msg)
+	if err != nil {
+		t.Fatalf("unexpected err from encrypt string %q: %+v\n", msg, err)
+	}
+	token2, err := enc.Encrypt(ctx, msg)
+	if err != nil {
+		t.Fatalf("unexpected err from encrypt string %q: %+v\n", msg, err)
+	}
+
+	if token1 == token2 {
+		t.Error("expected two encryptions of the same message to produce different tokens")
+	}
+
+	// Tokens differ even under a single data key, because of the random nonce, so compare
+	// the unwrapped data keys themselves.
+	var deks [2][]byte
+	for i, tok := range []string{token1, token2} {
+		var parsed token
+		if err := json.Unmarshal([]byte(tok), &parsed); err != nil {
+			t.Fatalf("unexpected err unmarshalling token %q: %+v\n", tok, err)
+		}
+		wrapped, err := base64.RawURLEncoding.DecodeString(parsed.WrappedKey)
+		if err != nil {
+			t.Fatalf("unexpected err decoding wrapped key %q: %+v\n", parsed.WrappedKey, err)
+		}
+		if deks[i], err = provider.UnwrapKey(ctx, wrapped, parsed.KeyID); err != nil {
+			t.Fatalf("unexpected err unwrapping data key: %+v\n", err)
+		}
+	}
+	if bytes.Equal(deks[0], deks[1]) {
+		t.Error("expected each message to be encrypted under a different data key")
+	}
+}
+
+func TestUnwrapKeyUnknownID(t *testing.T) {
+	provider := NewStaticKeyProvider(mustKEK(t), "kek-1")
+
+	_, err := provider.UnwrapKey(context.Background(), []byte("wrapped"), "kek-2")
+	wantErr := `unknown key id "kek-2"`
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("expected error %q, got %q", wantErr, err)
+	}
+}
+
+func TestDecryptMalformedToken(t *testing.T) {
+	enc := New(NewStaticKeyProvider(mustKEK(t), "kek-1"))
+
+	_, err := enc.Decrypt(context.Background(), "not json")
+	if err == nil {
+		t.Error("expected an error decrypting a malformed token")
+	}
+}
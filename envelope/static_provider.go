@@ -0,0 +1,48 @@
+package envelope
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sudo-suhas/symcrypto"
+)
+
+// StaticKeyProvider is a KeyProvider backed by a single, statically configured KEK. It
+// wraps data keys using a symcrypto.Crypter, so no external KMS is required. It is
+// intended for tests and for deployments which don't yet have a KMS - use a
+// symcrypto.Keyring backed provider instead once key rotation is needed.
+type StaticKeyProvider struct {
+	kek symcrypto.Crypter
+	id  string
+}
+
+// NewStaticKeyProvider creates a StaticKeyProvider which wraps data keys with kek,
+// identifying itself as id so that a caller juggling more than one KeyProvider can route
+// UnwrapKey calls to the right one.
+func NewStaticKeyProvider(kek symcrypto.Crypter, id string) *StaticKeyProvider {
+	return &StaticKeyProvider{kek: kek, id: id}
+}
+
+// WrapKey implements KeyProvider.
+func (p *StaticKeyProvider) WrapKey(_ context.Context, dek []byte) ([]byte, string, error) {
+	wrapped, err := p.kek.Encrypt(string(dek))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to wrap data key")
+	}
+
+	return []byte(wrapped), p.id, nil
+}
+
+// UnwrapKey implements KeyProvider.
+func (p *StaticKeyProvider) UnwrapKey(_ context.Context, wrapped []byte, keyID string) ([]byte, error) {
+	if keyID != p.id {
+		return nil, errors.Errorf("unknown key id %q", keyID)
+	}
+
+	dek, err := p.kek.Decrypt(string(wrapped))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unwrap data key")
+	}
+
+	return []byte(dek), nil
+}
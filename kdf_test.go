@@ -0,0 +1,87 @@
+package symcrypto
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewFromPassphrase(t *testing.T) {
+	for _, kdf := range []KDF{KDFScrypt, KDFArgon2id} {
+		t.Run(fmt.Sprintf("kdf=%d", kdf), func(t *testing.T) {
+			crypto, err := NewFromPassphrase("hunter2", WithKDF(kdf))
+			if err != nil {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+
+			msg := "hello world"
+			encrypted, err := crypto.Encrypt(msg)
+			if err != nil {
+				t.Fatalf("unexpected err from encrypt string %q: %+v\n", msg, err)
+			}
+
+			decrypted, err := crypto.Decrypt(encrypted)
+			if err != nil {
+				t.Fatalf("unexpected err from decrypt string %q: %+v\n", encrypted, err)
+			}
+			if decrypted != msg {
+				t.Errorf("expected decrypted string to be %q, got %q\n", msg, decrypted)
+			}
+		})
+	}
+}
+
+func TestPassphraseDecryptWithoutStoredSalt(t *testing.T) {
+	// Every call to Encrypt mints its own random salt and embeds it in the token, so two
+	// independently constructed Crypters sharing a passphrase must still decrypt each
+	// other's tokens.
+	c1, err := NewFromPassphrase("hunter2")
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	c2, err := NewFromPassphrase("hunter2")
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+
+	msg := "hello world"
+	encrypted, err := c1.Encrypt(msg)
+	if err != nil {
+		t.Fatalf("unexpected err from encrypt string %q: %+v\n", msg, err)
+	}
+
+	decrypted, err := c2.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("unexpected err from decrypt string %q: %+v\n", encrypted, err)
+	}
+	if decrypted != msg {
+		t.Errorf("expected decrypted string to be %q, got %q\n", msg, decrypted)
+	}
+}
+
+func TestPassphraseDecryptErrors(t *testing.T) {
+	crypto, err := NewFromPassphrase("hunter2")
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+
+	errCases := []struct {
+		name, msg, wantErr string
+	}{
+		{"illegal base64", "/", `failed to decode "/" using base64: illegal base64 data at input byte 0`},
+		{"invalid(empty) encrypted msg", "", `invalid encrypted message, "" is too short`},
+		{
+			"arbitrary string",
+			"some_string_which_was_not_encrypted_using_symcrypto_padding_ext",
+			`failed to decrypt "some_string_which_was_not_encrypted_using_symcrypto_padding_ext"`,
+		},
+	}
+
+	for _, c := range errCases {
+		t.Run("err/"+c.name, func(t *testing.T) {
+			_, err := crypto.Decrypt(c.msg)
+			if err == nil || err.Error() != c.wantErr {
+				t.Errorf("expected error %q, got %q", c.wantErr, err)
+			}
+		})
+	}
+}
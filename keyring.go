@@ -0,0 +1,171 @@
+package symcrypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Key is a 32 byte secret key, as used by New and Keyring.
+type Key [SecretKeyLen]byte
+
+// KeyEntry is a single key held by a Keyring, identified by ID.
+type KeyEntry struct {
+	ID  uint8
+	Key Key
+}
+
+// fingerprint derives a key's ID from the key itself, as the first byte of its SHA-256
+// hash. Unlike a positional index, this is stable across independently constructed
+// Keyrings holding the same key, and lets Decrypt tell "a key I don't have" apart from
+// "a key which happens to share another key's position".
+func fingerprint(key Key) uint8 {
+	sum := sha256.Sum256(key[:])
+	return sum[0]
+}
+
+// ErrUnknownKey is returned by (*Keyring).Decrypt when a token was encrypted with a key
+// ID the Keyring doesn't hold. Callers can use this to detect tokens which need to be
+// re-encrypted under a currently known key - see (*Keyring).ReEncrypt.
+var ErrUnknownKey = errors.New("symcrypto: unknown key id")
+
+// Keyring is a Crypter which holds multiple keys, identified by ID, with one marked as
+// primary. Encrypt always uses the primary key, and prepends its ID to the token so that
+// Decrypt can pick the right key even after the primary has changed. This allows secret
+// keys to be rotated without invalidating tokens which were encrypted under an older
+// key.
+//
+// Unlike crypter, Keyring is exported so that callers can hold on to the concrete type
+// in order to call Rotate and ReEncrypt; it still satisfies the Crypter interface.
+type Keyring struct {
+	entries   []KeyEntry
+	byID      map[uint8]Key
+	primaryID uint8
+}
+
+// NewKeyring creates a Keyring with primary as the initial primary key, plus any
+// additional keys which should still be accepted for decryption.
+func NewKeyring(primary Key, others ...Key) (*Keyring, error) {
+	kr := &Keyring{byID: make(map[uint8]Key, 1+len(others))}
+	if err := kr.addKey(primary); err != nil {
+		return nil, err
+	}
+	kr.primaryID = fingerprint(primary)
+
+	for _, key := range others {
+		if err := kr.addKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return kr, nil
+}
+
+// Rotate adds newPrimary to the keyring and marks it as the primary key used for
+// subsequent calls to Encrypt. Older keys, including the previous primary, remain in the
+// keyring so that tokens encrypted under them can still be decrypted.
+func (kr *Keyring) Rotate(newPrimary Key) error {
+	if err := kr.addKey(newPrimary); err != nil {
+		return err
+	}
+	kr.primaryID = fingerprint(newPrimary)
+	return nil
+}
+
+// addKey registers key under the ID derived from it. Since the ID is only a single byte,
+// two different keys can, with low probability, hash to the same ID; treat that as an
+// error rather than silently letting one key shadow the other.
+func (kr *Keyring) addKey(key Key) error {
+	id := fingerprint(key)
+	if existing, ok := kr.byID[id]; ok {
+		if existing == key {
+			return nil
+		}
+		return errors.Errorf("symcrypto: key id %d collides with an existing, different key", id)
+	}
+
+	kr.entries = append(kr.entries, KeyEntry{ID: id, Key: key})
+	kr.byID[id] = key
+	return nil
+}
+
+func (kr *Keyring) Encrypt(msg string) (string, error) {
+	key := kr.byID[kr.primaryID]
+
+	// Use a different nonce for each message encrypted with the same key. Since the
+	// nonce here is 192 bits long, a random value provides a sufficiently small
+	// probability of repeats.
+	var nonce [nonceLen]byte
+	if _, err := rand.Reader.Read(nonce[:]); err != nil {
+		return "", errors.Wrap(err, "failed to generate nonce")
+	}
+
+	// The token is laid out as: 1 byte key ID, nonce, ciphertext.
+	prefixed := append([]byte{kr.primaryID}, nonce[:]...)
+	sealedKey := [SecretKeyLen]byte(key)
+	sealed := secretbox.Seal(prefixed, []byte(msg), &nonce, &sealedKey)
+
+	return encoding.EncodeToString(sealed), nil
+}
+
+func (kr *Keyring) Decrypt(msg string) (string, error) {
+	crypticBytes, err := encoding.DecodeString(msg)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decode %q using base64", msg)
+	}
+	if len(crypticBytes) <= 1+nonceLen {
+		// The encrypted message can't possibly be shorter than the key ID byte plus the
+		// nonce! By doing this check, we also avoid a panic:
+		// panic: runtime error: slice bounds out of range
+		return "", errors.Errorf("invalid encrypted message, %q is too short", msg)
+	}
+
+	id := crypticBytes[0]
+	key, ok := kr.byID[id]
+	if !ok {
+		return "", errors.Wrapf(ErrUnknownKey, "key id %d", id)
+	}
+
+	var nonce [nonceLen]byte
+	copy(nonce[:], crypticBytes[1:1+nonceLen])
+
+	sealedKey := [SecretKeyLen]byte(key)
+	decrypted, ok := secretbox.Open(nil, crypticBytes[1+nonceLen:], &nonce, &sealedKey)
+	if !ok {
+		return "", errors.Errorf("failed to decrypt %q", msg)
+	}
+
+	return string(decrypted), nil
+}
+
+// ReEncrypt decrypts token with whichever known key it was encrypted under, and if that
+// key isn't the current primary, re-encrypts the plaintext under the primary key. The
+// returned bool reports whether re-encryption happened, so callers can persist the new
+// token only when it actually changed.
+func (kr *Keyring) ReEncrypt(token string) (string, bool, error) {
+	crypticBytes, err := encoding.DecodeString(token)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to decode %q using base64", token)
+	}
+	if len(crypticBytes) <= 1+nonceLen {
+		return "", false, errors.Errorf("invalid encrypted message, %q is too short", token)
+	}
+
+	msg, err := kr.Decrypt(token)
+	if err != nil {
+		return "", false, err
+	}
+
+	if crypticBytes[0] == kr.primaryID {
+		return token, false, nil
+	}
+
+	reencrypted, err := kr.Encrypt(msg)
+	if err != nil {
+		return "", false, err
+	}
+
+	return reencrypted, true, nil
+}
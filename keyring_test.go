@@ -0,0 +1,153 @@
+package symcrypto
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func mustKey(t *testing.T, secret string) Key {
+	crypto := getCrypto(t, secret)
+	return Key(crypto.(*crypter).secretKey)
+}
+
+func TestKeyringE2E(t *testing.T) {
+	k1 := mustKey(t, "secret_key_with_string_length_32")
+	k2 := mustKey(t, "some_other_different_secret_key_")
+
+	kr, err := NewKeyring(k1, k2)
+	if err != nil {
+		t.Fatalf("could not create an instance of Keyring: %+v\n", err)
+	}
+
+	msg := "hello world"
+	encrypted, err := kr.Encrypt(msg)
+	if err != nil {
+		t.Fatalf("unexpected err from encrypt string %q: %+v\n", msg, err)
+	}
+
+	decrypted, err := kr.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("unexpected err from decrypt string %q: %+v\n", encrypted, err)
+	}
+	if decrypted != msg {
+		t.Errorf("expected decrypted string to be %q, got %q\n", msg, decrypted)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	k1 := mustKey(t, "secret_key_with_string_length_32")
+	k2 := mustKey(t, "some_other_different_secret_key_")
+
+	kr, err := NewKeyring(k1)
+	if err != nil {
+		t.Fatalf("could not create an instance of Keyring: %+v\n", err)
+	}
+
+	msg := "hello world"
+	oldToken, err := kr.Encrypt(msg)
+	if err != nil {
+		t.Fatalf("unexpected err from encrypt string %q: %+v\n", msg, err)
+	}
+
+	if err := kr.Rotate(k2); err != nil {
+		t.Fatalf("unexpected error rotating keyring: %+v\n", err)
+	}
+
+	// Tokens minted under the old primary key must still decrypt after rotation.
+	decrypted, err := kr.Decrypt(oldToken)
+	if err != nil {
+		t.Fatalf("unexpected err from decrypt string %q: %+v\n", oldToken, err)
+	}
+	if decrypted != msg {
+		t.Errorf("expected decrypted string to be %q, got %q\n", msg, decrypted)
+	}
+
+	// New tokens must be encrypted under the new primary key.
+	newToken, err := kr.Encrypt(msg)
+	if err != nil {
+		t.Fatalf("unexpected err from encrypt string %q: %+v\n", msg, err)
+	}
+
+	t.Run("re-encrypt", func(t *testing.T) {
+		reencrypted, changed, err := kr.ReEncrypt(oldToken)
+		if err != nil {
+			t.Fatalf("unexpected err from re-encrypt string %q: %+v\n", oldToken, err)
+		}
+		if !changed {
+			t.Errorf("expected token encrypted under an old key to be re-encrypted")
+		}
+
+		decrypted, err := kr.Decrypt(reencrypted)
+		if err != nil {
+			t.Fatalf("unexpected err from decrypt string %q: %+v\n", reencrypted, err)
+		}
+		if decrypted != msg {
+			t.Errorf("expected decrypted string to be %q, got %q\n", msg, decrypted)
+		}
+
+		_, changed, err = kr.ReEncrypt(newToken)
+		if err != nil {
+			t.Fatalf("unexpected err from re-encrypt string %q: %+v\n", newToken, err)
+		}
+		if changed {
+			t.Errorf("expected token already encrypted under the primary key to be left alone")
+		}
+	})
+}
+
+func TestKeyringUnknownKey(t *testing.T) {
+	k1 := mustKey(t, "secret_key_with_string_length_32")
+	k2 := mustKey(t, "some_other_different_secret_key_")
+
+	minted, err := NewKeyring(k2)
+	if err != nil {
+		t.Fatalf("could not create an instance of Keyring: %+v\n", err)
+	}
+	reader, err := NewKeyring(k1)
+	if err != nil {
+		t.Fatalf("could not create an instance of Keyring: %+v\n", err)
+	}
+
+	msg := "hello world"
+	encrypted, err := minted.Encrypt(msg)
+	if err != nil {
+		t.Fatalf("unexpected err from encrypt string %q: %+v\n", msg, err)
+	}
+
+	_, err = reader.Decrypt(encrypted)
+	if errors.Cause(err) != ErrUnknownKey {
+		t.Errorf("expected error to wrap ErrUnknownKey, got %q", err)
+	}
+}
+
+func TestKeyringRepeatedKeyIsNotAnError(t *testing.T) {
+	k1 := mustKey(t, "secret_key_with_string_length_32")
+
+	if _, err := NewKeyring(k1, k1); err != nil {
+		t.Fatalf("unexpected error registering the same key twice: %+v\n", err)
+	}
+}
+
+func TestKeyringDecryptErrors(t *testing.T) {
+	kr, err := NewKeyring(mustKey(t, "secret_key_with_string_length_32"))
+	if err != nil {
+		t.Fatalf("could not create an instance of Keyring: %+v\n", err)
+	}
+
+	errCases := []struct {
+		name, msg, wantErr string
+	}{
+		{"illegal base64", "/", `failed to decode "/" using base64: illegal base64 data at input byte 0`},
+		{"invalid(empty) encrypted msg", "", `invalid encrypted message, "" is too short`},
+	}
+
+	for _, c := range errCases {
+		t.Run("err/"+c.name, func(t *testing.T) {
+			_, err := kr.Decrypt(c.msg)
+			if err == nil || err.Error() != c.wantErr {
+				t.Errorf("expected error %q, got %q", c.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package symcrypto
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// DefaultChunkSize is the chunk size used by NewEncryptWriter when no WithChunkSize
+// option is given. secretbox.Seal is documented as suitable for encrypting small
+// messages, so large payloads are split into chunks of this size and sealed one at a
+// time instead of as a single blob.
+const DefaultChunkSize = 64 * 1024
+
+// finalChunk and nonFinalChunk are prepended to a chunk's plaintext before sealing, so
+// that whether a chunk is the last one in the stream is authenticated along with the
+// chunk itself - see the framing comment below.
+const (
+	nonFinalChunk byte = 0
+	finalChunk    byte = 1
+)
+
+// StreamCrypter is implemented by Crypters which can encrypt/decrypt payloads too large
+// to comfortably fit in memory as a single message. *crypter, as returned by New,
+// implements this in addition to Crypter.
+type StreamCrypter interface {
+	// NewEncryptWriter returns a WriteCloser which encrypts everything written to it and
+	// writes the result to w. Close must be called to flush the final chunk.
+	NewEncryptWriter(w io.Writer, opts ...StreamOption) (io.WriteCloser, error)
+
+	// NewDecryptReader returns a ReadCloser which reads and decrypts a stream produced by
+	// NewEncryptWriter from r.
+	NewDecryptReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// StreamOption configures the encrypting writer returned by NewEncryptWriter.
+type StreamOption func(*streamConfig)
+
+type streamConfig struct {
+	chunkSize int
+}
+
+// WithChunkSize overrides DefaultChunkSize as the plaintext size of each sealed chunk.
+func WithChunkSize(n int) StreamOption {
+	return func(cfg *streamConfig) { cfg.chunkSize = n }
+}
+
+// The stream is framed as: a 24 byte base nonce, followed by records of
+// [4 byte big endian length][secretbox(final byte || chunk)]. Each chunk is sealed with
+// a nonce derived by XORing an 8 byte little endian counter into the base nonce, so no
+// two chunks ever reuse a nonce. The leading byte of each chunk's plaintext is
+// finalChunk or nonFinalChunk, marking whether it's the last chunk in the stream; since
+// this byte is part of the sealed data, an attacker can't forge it without the secret
+// key, so a stream truncated after a non-final chunk is detected rather than silently
+// accepted as complete. The length prefix itself carries no such flag - it's just the
+// length of the sealed record that follows.
+
+func chunkNonce(base [nonceLen]byte, counter uint64) [nonceLen]byte {
+	nonce := base
+	var ctr [8]byte
+	binary.LittleEndian.PutUint64(ctr[:], counter)
+	for i, b := range ctr {
+		nonce[i] ^= b
+	}
+	return nonce
+}
+
+type encryptWriter struct {
+	w         io.Writer
+	secretKey *[SecretKeyLen]byte
+	nonce     [nonceLen]byte
+	counter   uint64
+	chunkSize int
+	buf       []byte
+	closed    bool
+}
+
+// NewEncryptWriter implements StreamCrypter.
+func (c *crypter) NewEncryptWriter(w io.Writer, opts ...StreamOption) (io.WriteCloser, error) {
+	cfg := &streamConfig{chunkSize: DefaultChunkSize}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.chunkSize <= 0 {
+		return nil, errInput("chunk size", 1, cfg.chunkSize)
+	}
+
+	var nonce [nonceLen]byte
+	if _, err := rand.Reader.Read(nonce[:]); err != nil {
+		return nil, errors.Wrap(err, "failed to generate stream nonce")
+	}
+	if _, err := w.Write(nonce[:]); err != nil {
+		return nil, errors.Wrap(err, "failed to write stream nonce header")
+	}
+
+	return &encryptWriter{
+		w:         w,
+		secretKey: &c.secretKey,
+		nonce:     nonce,
+		chunkSize: cfg.chunkSize,
+		buf:       make([]byte, 0, cfg.chunkSize),
+	}, nil
+}
+
+func (ew *encryptWriter) Write(p []byte) (int, error) {
+	if ew.closed {
+		return 0, errors.New("symcrypto: write to closed stream")
+	}
+
+	ew.buf = append(ew.buf, p...)
+	for len(ew.buf) >= ew.chunkSize {
+		if err := ew.sealChunk(ew.buf[:ew.chunkSize], false); err != nil {
+			return 0, err
+		}
+		ew.buf = ew.buf[ew.chunkSize:]
+	}
+
+	return len(p), nil
+}
+
+func (ew *encryptWriter) Close() error {
+	if ew.closed {
+		return nil
+	}
+	ew.closed = true
+
+	return ew.sealChunk(ew.buf, true)
+}
+
+func (ew *encryptWriter) sealChunk(chunk []byte, final bool) error {
+	nonce := chunkNonce(ew.nonce, ew.counter)
+	ew.counter++
+
+	flag := nonFinalChunk
+	if final {
+		flag = finalChunk
+	}
+	tagged := append([]byte{flag}, chunk...)
+	sealed := secretbox.Seal(nil, tagged, &nonce, ew.secretKey)
+
+	if len(sealed) > math.MaxUint32 {
+		return errors.New("symcrypto: chunk size too large to encode")
+	}
+	length := uint32(len(sealed))
+
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], length)
+	if _, err := ew.w.Write(lenBuf[:]); err != nil {
+		return errors.Wrap(err, "failed to write chunk length")
+	}
+	if _, err := ew.w.Write(sealed); err != nil {
+		return errors.Wrap(err, "failed to write chunk")
+	}
+
+	return nil
+}
+
+type decryptReader struct {
+	r         io.Reader
+	secretKey *[SecretKeyLen]byte
+	nonce     [nonceLen]byte
+	counter   uint64
+	buf       []byte
+	eof       bool
+}
+
+// NewDecryptReader implements StreamCrypter.
+func (c *crypter) NewDecryptReader(r io.Reader) (io.ReadCloser, error) {
+	var nonce [nonceLen]byte
+	if _, err := io.ReadFull(r, nonce[:]); err != nil {
+		return nil, errors.Wrap(err, "failed to read stream nonce header")
+	}
+
+	return &decryptReader{r: r, secretKey: &c.secretKey, nonce: nonce}, nil
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.buf) == 0 && !dr.eof {
+		if err := dr.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	if len(dr.buf) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p, dr.buf)
+	dr.buf = dr.buf[n:]
+	return n, nil
+}
+
+func (dr *decryptReader) Close() error { return nil }
+
+func (dr *decryptReader) readChunk() error {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(dr.r, lenBuf[:]); err != nil {
+		// The stream ended before a chunk announced itself as final - this is a
+		// truncation, not a clean end of stream, so it must not be treated as io.EOF.
+		return errors.Wrap(err, "symcrypto: truncated stream")
+	}
+
+	length := binary.BigEndian.Uint32(lenBuf[:])
+
+	sealed := make([]byte, length)
+	if _, err := io.ReadFull(dr.r, sealed); err != nil {
+		return errors.Wrap(err, "symcrypto: truncated stream")
+	}
+
+	nonce := chunkNonce(dr.nonce, dr.counter)
+	dr.counter++
+
+	tagged, ok := secretbox.Open(nil, sealed, &nonce, dr.secretKey)
+	if !ok {
+		return errors.New("symcrypto: failed to decrypt chunk")
+	}
+	if len(tagged) == 0 {
+		return errors.New("symcrypto: chunk missing final-chunk marker")
+	}
+
+	dr.buf = append(dr.buf, tagged[1:]...)
+	if tagged[0] == finalChunk {
+		dr.eof = true
+	}
+
+	return nil
+}